@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Format lets Translator read and write translation files in a particular
+// serialization, keyed by file extension.
+type Format interface {
+	// Extensions returns the file extensions (without the leading dot)
+	// handled by this format. The first one is used when writing new files.
+	Extensions() []string
+	// Unmarshal parses raw file contents into a nested key/value tree.
+	Unmarshal([]byte) (map[string]interface{}, error)
+	// Marshal serializes a nested key/value tree back to raw file contents.
+	// indent requests human-readable formatting where the format supports it.
+	Marshal(data map[string]interface{}, indent bool) ([]byte, error)
+}
+
+// formats is the registry of known formats, keyed by lower-cased extension.
+var formats = make(map[string]Format)
+
+// RegisterFormat makes a Format available to Translator.Load under each of
+// its extensions, overwriting any previously registered format for the same
+// extension.
+func RegisterFormat(f Format) {
+	for _, ext := range f.Extensions() {
+		formats[strings.ToLower(ext)] = f
+	}
+}
+
+// formatForExt looks up a registered format by extension (without the
+// leading dot).
+func formatForExt(ext string) (Format, bool) {
+	f, ok := formats[strings.ToLower(ext)]
+	return f, ok
+}
+
+func init() {
+	RegisterFormat(jsonFormat{})
+	RegisterFormat(yamlFormat{})
+	RegisterFormat(tomlFormat{})
+}
+
+// jsonFormat is the original, built-in translation file format.
+type jsonFormat struct{}
+
+func (jsonFormat) Extensions() []string { return []string{"json"} }
+
+func (jsonFormat) Unmarshal(b []byte) (map[string]interface{}, error) {
+	var data map[string]interface{}
+	err := json.Unmarshal(b, &data)
+	return data, err
+}
+
+func (jsonFormat) Marshal(data map[string]interface{}, indent bool) ([]byte, error) {
+	if indent {
+		return json.MarshalIndent(data, "", "  ")
+	}
+	return json.Marshal(data)
+}
+
+// yamlFormat loads and saves translations as YAML.
+type yamlFormat struct{}
+
+func (yamlFormat) Extensions() []string { return []string{"yaml", "yml"} }
+
+func (yamlFormat) Unmarshal(b []byte) (map[string]interface{}, error) {
+	var raw map[interface{}]interface{}
+	if err := yaml.Unmarshal(b, &raw); err != nil {
+		return nil, err
+	}
+	return stringifyKeys(raw), nil
+}
+
+func (yamlFormat) Marshal(data map[string]interface{}, indent bool) ([]byte, error) {
+	return yaml.Marshal(data)
+}
+
+// tomlFormat loads and saves translations as TOML.
+type tomlFormat struct{}
+
+func (tomlFormat) Extensions() []string { return []string{"toml"} }
+
+func (tomlFormat) Unmarshal(b []byte) (map[string]interface{}, error) {
+	var data map[string]interface{}
+	if _, err := toml.Decode(string(b), &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (tomlFormat) Marshal(data map[string]interface{}, indent bool) ([]byte, error) {
+	var buf strings.Builder
+	if err := toml.NewEncoder(&buf).Encode(data); err != nil {
+		return nil, err
+	}
+	return []byte(buf.String()), nil
+}
+
+// stringifyKeys recursively converts the map[interface{}]interface{} values
+// produced by yaml.v2 into map[string]interface{}, as expected by
+// Translator.extractKeys.
+func stringifyKeys(m map[interface{}]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		key := fmt.Sprintf("%v", k)
+		switch child := v.(type) {
+		case map[interface{}]interface{}:
+			out[key] = stringifyKeys(child)
+		default:
+			out[key] = v
+		}
+	}
+	return out
+}
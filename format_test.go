@@ -0,0 +1,58 @@
+package main
+
+import "testing"
+
+func TestFormatForExt(t *testing.T) {
+	cases := []struct {
+		ext  string
+		want Format
+	}{
+		{"json", jsonFormat{}},
+		{"yaml", yamlFormat{}},
+		{"yml", yamlFormat{}},
+		{"toml", tomlFormat{}},
+	}
+	for _, c := range cases {
+		got, ok := formatForExt(c.ext)
+		if !ok {
+			t.Fatalf("formatForExt(%q) not found", c.ext)
+		}
+		if got != c.want {
+			t.Errorf("formatForExt(%q) = %T, want %T", c.ext, got, c.want)
+		}
+	}
+
+	if _, ok := formatForExt("ini"); ok {
+		t.Error("expected an unregistered extension to not be found")
+	}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	data := map[string]interface{}{
+		"greeting": "hello",
+		"nested": map[string]interface{}{
+			"farewell": "bye",
+		},
+	}
+
+	for _, format := range []Format{jsonFormat{}, yamlFormat{}, tomlFormat{}} {
+		b, err := format.Marshal(data, true)
+		if err != nil {
+			t.Fatalf("%T: Marshal failed: %v", format, err)
+		}
+		got, err := format.Unmarshal(b)
+		if err != nil {
+			t.Fatalf("%T: Unmarshal failed: %v", format, err)
+		}
+		if got["greeting"] != "hello" {
+			t.Errorf("%T: greeting = %v, want hello", format, got["greeting"])
+		}
+		nested, ok := got["nested"].(map[string]interface{})
+		if !ok {
+			t.Fatalf("%T: nested is %T, want map[string]interface{}", format, got["nested"])
+		}
+		if nested["farewell"] != "bye" {
+			t.Errorf("%T: farewell = %v, want bye", format, nested["farewell"])
+		}
+	}
+}
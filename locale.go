@@ -0,0 +1,49 @@
+package main
+
+import "strings"
+
+// T resolves key by walking a fallback chain of locales: each locale is
+// tried in order, and for each locale progressively shorter BCP-47 subtags
+// are tried too (e.g. "de-CH" falls back to "de"). Translator.DefaultLocale,
+// if set, is appended to the end of every chain. Returns key itself when
+// nothing in the chain matches.
+func (t *Translator) T(key string, locales ...string) string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	chain := locales
+	if t.DefaultLocale != "" {
+		chain = append(append([]string{}, locales...), t.DefaultLocale)
+	}
+	for _, locale := range chain {
+		for _, candidate := range localeSubtags(locale) {
+			trans, ok := t.languages[candidate]
+			if !ok {
+				continue
+			}
+			value, ok := trans[key]
+			if !ok {
+				continue
+			}
+			if value.IsPlural() {
+				if form, ok := value.Plurals[PluralOther]; ok {
+					return form
+				}
+				continue
+			}
+			return value.Template
+		}
+	}
+	return key
+}
+
+// localeSubtags returns locale together with each of its progressively
+// shorter BCP-47 prefixes, most specific first, e.g. "de-CH" -> ["de-CH",
+// "de"].
+func localeSubtags(locale string) []string {
+	parts := strings.Split(locale, "-")
+	subtags := make([]string, 0, len(parts))
+	for i := len(parts); i > 0; i-- {
+		subtags = append(subtags, strings.Join(parts[:i], "-"))
+	}
+	return subtags
+}
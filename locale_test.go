@@ -0,0 +1,42 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLocaleSubtags(t *testing.T) {
+	cases := []struct {
+		locale string
+		want   []string
+	}{
+		{"de-CH", []string{"de-CH", "de"}},
+		{"de", []string{"de"}},
+		{"zh-Hans-CN", []string{"zh-Hans-CN", "zh-Hans", "zh"}},
+	}
+	for _, c := range cases {
+		if got := localeSubtags(c.locale); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("localeSubtags(%q) = %v, want %v", c.locale, got, c.want)
+		}
+	}
+}
+
+func TestTranslatorT(t *testing.T) {
+	tr := &Translator{
+		languages: map[string]Language{
+			"de": {"greeting": Translation{Key: "greeting", Template: "Hallo"}},
+			"en": {"greeting": Translation{Key: "greeting", Template: "Hello"}},
+		},
+		DefaultLocale: "en",
+	}
+
+	if got := tr.T("greeting", "de-CH"); got != "Hallo" {
+		t.Errorf("T falling back de-CH -> de = %q, want %q", got, "Hallo")
+	}
+	if got := tr.T("greeting", "fr"); got != "Hello" {
+		t.Errorf("T should fall back to DefaultLocale, got %q, want %q", got, "Hello")
+	}
+	if got := tr.T("missing"); got != "missing" {
+		t.Errorf("T with no match should return the key itself, got %q", got)
+	}
+}
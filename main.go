@@ -1,12 +1,25 @@
 package main
 
 import (
+	"flag"
 	"log"
+	"os"
 )
 
 func main() {
 
-	t, err := NewTranslator("./test-data")
+	if len(os.Args) > 1 && os.Args[1] == "sync" {
+		t, err := NewTranslator("./test-data")
+		if err != nil {
+			log.Fatal(err)
+		}
+		runSync(t, os.Args[2:])
+		return
+	}
+
+	// watch the translation directory so edits on disk are picked up
+	// without a restart, and can be pushed to admin UIs via /events
+	t, err := NewTranslatorWithWatch("./test-data")
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -15,3 +28,17 @@ func main() {
 	server.Run()
 
 }
+
+// runSync implements `translator sync --source=en`: it merges the source
+// language into every other loaded language and writes the split
+// translated / untranslated files, mirroring the /languages/:lang/sync
+// endpoint.
+func runSync(t *Translator, args []string) {
+	fs := flag.NewFlagSet("sync", flag.ExitOnError)
+	source := fs.String("source", "en", "source language to sync from")
+	fs.Parse(args)
+
+	if err := t.SyncFiles(*source, false, true); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"math"
+	"strings"
+)
+
+// CLDR plural categories, in the order they are checked.
+const (
+	PluralZero  = "zero"
+	PluralOne   = "one"
+	PluralTwo   = "two"
+	PluralFew   = "few"
+	PluralMany  = "many"
+	PluralOther = "other"
+)
+
+// pluralCategories holds the full set of valid CLDR category names, used to
+// detect a nested plural-form object while walking a translation file.
+var pluralCategories = map[string]bool{
+	PluralZero:  true,
+	PluralOne:   true,
+	PluralTwo:   true,
+	PluralFew:   true,
+	PluralMany:  true,
+	PluralOther: true,
+}
+
+// pluralRuleFunc selects the CLDR category for a given number.
+type pluralRuleFunc func(n float64) string
+
+// pluralRules maps a BCP-47 language code to its CLDR plural rule. Only the
+// base language subtag is looked up; unknown locales fall back to
+// pluralOther below.
+var pluralRules = map[string]pluralRuleFunc{
+	"en": pluralOneOther,
+	"de": pluralOneOther,
+	"nl": pluralOneOther,
+	"es": pluralOneOther,
+	"it": pluralOneOther,
+	"sv": pluralOneOther,
+	"fr": pluralFrench,
+	"pt": pluralFrench,
+	"ru": pluralRussian,
+	"pl": pluralPolish,
+	"ar": pluralArabic,
+	"ja": pluralOther,
+	"zh": pluralOther,
+	"ko": pluralOther,
+	"vi": pluralOther,
+	"th": pluralOther,
+	"id": pluralOther,
+}
+
+// pluralOneOther is the common Germanic/Romance rule: singular for 1, plural
+// otherwise.
+func pluralOneOther(n float64) string {
+	if n == 1 {
+		return PluralOne
+	}
+	return PluralOther
+}
+
+// pluralFrench treats 0 and 1 as singular.
+func pluralFrench(n float64) string {
+	if n == 0 || n == 1 {
+		return PluralOne
+	}
+	return PluralOther
+}
+
+// pluralRussian implements the standard mod-10/mod-100 Slavic rule.
+func pluralRussian(n float64) string {
+	mod10 := mod(n, 10)
+	mod100 := mod(n, 100)
+	if !isInt(n) {
+		return PluralOther
+	}
+	if mod10 == 1 && mod100 != 11 {
+		return PluralOne
+	}
+	if mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14) {
+		return PluralFew
+	}
+	if mod10 == 0 || (mod10 >= 5 && mod10 <= 9) || (mod100 >= 11 && mod100 <= 14) {
+		return PluralMany
+	}
+	return PluralOther
+}
+
+// pluralPolish is the Slavic rule variant used by Polish.
+func pluralPolish(n float64) string {
+	if !isInt(n) {
+		return PluralOther
+	}
+	if n == 1 {
+		return PluralOne
+	}
+	mod10 := mod(n, 10)
+	mod100 := mod(n, 100)
+	if mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14) {
+		return PluralFew
+	}
+	return PluralMany
+}
+
+// pluralArabic implements the six-way Arabic rule.
+func pluralArabic(n float64) string {
+	if n == 0 {
+		return PluralZero
+	}
+	if n == 1 {
+		return PluralOne
+	}
+	if n == 2 {
+		return PluralTwo
+	}
+	mod100 := mod(n, 100)
+	if mod100 >= 3 && mod100 <= 10 {
+		return PluralFew
+	}
+	if mod100 >= 11 && mod100 <= 99 {
+		return PluralMany
+	}
+	return PluralOther
+}
+
+// pluralOther is used by languages without plural distinction (e.g. most
+// East Asian languages): every count maps to "other".
+func pluralOther(n float64) string {
+	return PluralOther
+}
+
+// mod returns n modulo m, using the number's absolute value so the Slavic
+// and Arabic rules above (which are only defined for non-negative n) still
+// behave sensibly for negative counts instead of falling through every
+// bound check.
+func mod(n, m float64) float64 {
+	return math.Mod(math.Abs(n), m)
+}
+
+func isInt(n float64) bool {
+	return n == float64(int64(n))
+}
+
+// pluralCategory selects the CLDR category for lang and n, defaulting to
+// "other" for unknown locales. Only the base language subtag (the part
+// before the first "-") is considered. CLDR plural rules are only defined
+// for non-negative numbers, so negative counts are categorized by their
+// absolute value, matching how most CLDR-rule implementations treat them.
+func pluralCategory(lang string, n float64) string {
+	base := lang
+	if idx := strings.Index(lang, "-"); idx != -1 {
+		base = lang[:idx]
+	}
+	if rule, ok := pluralRules[strings.ToLower(base)]; ok {
+		return rule(math.Abs(n))
+	}
+	return PluralOther
+}
+
+// asPluralForms reports whether m is a nested plural-form object - i.e. its
+// keys are exactly CLDR category names with string values - and returns it
+// converted to a plain map[string]string if so.
+func asPluralForms(m map[string]interface{}) (map[string]string, bool) {
+	if len(m) == 0 {
+		return nil, false
+	}
+	forms := make(map[string]string, len(m))
+	for k, v := range m {
+		if !pluralCategories[k] {
+			return nil, false
+		}
+		s, ok := v.(string)
+		if !ok {
+			return nil, false
+		}
+		forms[k] = s
+	}
+	return forms, true
+}
+
+// isValidPluralForms reports whether forms is non-empty and every key is a
+// CLDR category name, the same rule asPluralForms applies when detecting a
+// plural object on file load.
+func isValidPluralForms(forms map[string]string) bool {
+	if len(forms) == 0 {
+		return false
+	}
+	for category := range forms {
+		if !pluralCategories[category] {
+			return false
+		}
+	}
+	return true
+}
+
+// toFloat converts the interface{} counts accepted by GetPlural into a
+// float64. Unsupported types are treated as 0.
+func toFloat(count interface{}) float64 {
+	switch v := count.(type) {
+	case int:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case float64:
+		return v
+	default:
+		return 0
+	}
+}
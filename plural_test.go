@@ -0,0 +1,115 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPluralCategory(t *testing.T) {
+	cases := []struct {
+		lang string
+		n    float64
+		want string
+	}{
+		{"en", 1, PluralOne},
+		{"en", 2, PluralOther},
+		{"en", 0, PluralOther},
+		{"fr", 0, PluralOne},
+		{"fr", 1, PluralOne},
+		{"fr", 2, PluralOther},
+		{"ru", 1, PluralOne},
+		{"ru", 2, PluralFew},
+		{"ru", 5, PluralMany},
+		{"ru", 11, PluralMany},
+		{"ru", 21, PluralOne},
+		{"ar", 0, PluralZero},
+		{"ar", 1, PluralOne},
+		{"ar", 2, PluralTwo},
+		{"ar", 5, PluralFew},
+		{"ar", 20, PluralMany},
+		{"ar", 100, PluralOther},
+		{"ja", 5, PluralOther},
+		{"de-CH", 1, PluralOne},
+		{"xx", 1, PluralOther},
+		{"ru", -21, PluralOne},
+		{"en", -1, PluralOne},
+	}
+	for _, c := range cases {
+		if got := pluralCategory(c.lang, c.n); got != c.want {
+			t.Errorf("pluralCategory(%q, %v) = %q, want %q", c.lang, c.n, got, c.want)
+		}
+	}
+}
+
+func TestPluralCategoryLargeCountDoesNotHang(t *testing.T) {
+	done := make(chan string, 1)
+	go func() { done <- pluralCategory("ru", 1e11) }()
+	select {
+	case got := <-done:
+		if got != PluralMany {
+			t.Errorf("pluralCategory(ru, 1e11) = %q, want %q", got, PluralMany)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("pluralCategory did not return within 2s for a large count")
+	}
+}
+
+func TestAsPluralForms(t *testing.T) {
+	forms, ok := asPluralForms(map[string]interface{}{
+		"one":   "item",
+		"other": "items",
+	})
+	if !ok {
+		t.Fatal("expected CLDR category map to be recognized as plural forms")
+	}
+	if forms["one"] != "item" || forms["other"] != "items" {
+		t.Errorf("unexpected forms: %+v", forms)
+	}
+
+	if _, ok := asPluralForms(map[string]interface{}{"foo": "bar"}); ok {
+		t.Error("expected a non-CLDR key map to be rejected")
+	}
+
+	if _, ok := asPluralForms(map[string]interface{}{"one": 42}); ok {
+		t.Error("expected a non-string value to be rejected")
+	}
+
+	if _, ok := asPluralForms(map[string]interface{}{}); ok {
+		t.Error("expected an empty map to be rejected")
+	}
+}
+
+func TestIsValidPluralForms(t *testing.T) {
+	if !isValidPluralForms(map[string]string{"one": "item", "other": "items"}) {
+		t.Error("expected CLDR-keyed map to be valid")
+	}
+	if isValidPluralForms(map[string]string{"foo": "bar"}) {
+		t.Error("expected non-CLDR key to be invalid")
+	}
+	if isValidPluralForms(map[string]string{}) {
+		t.Error("expected empty map to be invalid")
+	}
+}
+
+func TestGetPlural(t *testing.T) {
+	tr := &Translator{
+		languages: map[string]Language{
+			"en": {
+				"items": Translation{Key: "items", Plurals: map[string]string{
+					PluralOne:   "one item",
+					PluralOther: "{{.Count}} items",
+				}},
+			},
+		},
+	}
+
+	if got := tr.GetPlural("items", "en", 1); got != "one item" {
+		t.Errorf("GetPlural(1) = %q, want %q", got, "one item")
+	}
+	if got := tr.GetPlural("items", "en", 5); got != "{{.Count}} items" {
+		t.Errorf("GetPlural(5) = %q, want %q", got, "{{.Count}} items")
+	}
+	if got := tr.GetPlural("missing", "en", 5); got != "missing" {
+		t.Errorf("GetPlural for missing key = %q, want key itself", got)
+	}
+}
@@ -1,10 +1,14 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/labstack/echo"
 	"github.com/labstack/echo/engine/standard"
 	"net/http"
 	"github.com/labstack/echo/middleware"
+	"strings"
 )
 
 type Server struct {
@@ -25,6 +29,11 @@ func NewServer(translator *Translator) *Server {
 	s.echo.GET("/languages/:lang/translations", s.TranslationList)
 	s.echo.POST("/languages/:lang/translations", s.TranslationCreate)
 	s.echo.GET("/languages/:lang/translations/:id", s.TranslationOne)
+	s.echo.POST("/languages/:lang/translations/:id/render", s.TranslationRender)
+	s.echo.POST("/languages/:lang/translations/bulk", s.TranslationBulk)
+	s.echo.POST("/languages/:lang/sync", s.LanguageSync)
+	s.echo.GET("/t", s.Translate)
+	s.echo.GET("/events", s.Events)
 
 	s.echo.Use(middleware.Logger())
 
@@ -87,21 +96,89 @@ func (s *Server) TranslationList(c echo.Context) error {
 	return c.JSON(http.StatusOK, language["translations"])
 }
 
+// translationPayload accepts either a plain string template or an object of
+// CLDR plural forms for the "template" field.
+type translationPayload struct {
+	ID       string          `json:"id"`
+	Template json.RawMessage `json:"template"`
+}
+
 func (s *Server) TranslationCreate(c echo.Context) error {
-	var req map[string]string
+	var req translationPayload
 	err := c.Bind(&req)
 	if err != nil {
 		return s.handleError(err)
 	}
-	err = s.translator.Set(req["id"], req["template"], c.Param("lang"))
+	var template string
+	var forms map[string]string
+	if json.Unmarshal(req.Template, &template) == nil {
+		err = s.translator.Set(req.ID, template, c.Param("lang"))
+	} else if json.Unmarshal(req.Template, &forms) == nil {
+		err = s.translator.SetPlural(req.ID, forms, c.Param("lang"))
+	} else {
+		return s.handleError(errors.New("template must be a string or a plural-form object"))
+	}
 	if err != nil {
 		return s.handleError(err)
 	}
 	// save
 	err = s.translator.Save(true)
+	if err != nil {
+		return s.handleError(err)
+	}
 	return c.JSON(http.StatusCreated, req)
 }
 
+// LanguageSync merges the :lang path param, used as the source language,
+// into every other loaded language and writes the split translated /
+// untranslated files.
+func (s *Server) LanguageSync(c echo.Context) error {
+	err := s.translator.SyncFiles(c.Param("lang"), false, true)
+	if err != nil {
+		return s.handleError(err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// TranslationBulk accepts a completed untranslated file and folds it back
+// into :lang, clearing the untranslated flag on every key it contains.
+func (s *Server) TranslationBulk(c echo.Context) error {
+	var req map[string]interface{}
+	err := c.Bind(&req)
+	if err != nil {
+		return s.handleError(err)
+	}
+	err = s.translator.Bulk(c.Param("lang"), req)
+	if err != nil {
+		return s.handleError(err)
+	}
+	// save
+	err = s.translator.Save(true)
+	if err != nil {
+		return s.handleError(err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// TranslationRender renders :id in :lang through text/template against the
+// JSON body of variables, selecting a plural form for data["Count"] first
+// when the translation holds plural forms.
+func (s *Server) TranslationRender(c echo.Context) error {
+	var data map[string]interface{}
+	err := c.Bind(&data)
+	if err != nil {
+		return s.handleError(err)
+	}
+	rendered, err := s.translator.Render(c.Param("id"), c.Param("lang"), data)
+	if err != nil {
+		return s.handleError(err)
+	}
+	return c.JSON(http.StatusOK, map[string]string{
+		"id":     c.Param("id"),
+		"result": rendered,
+	})
+}
+
 func (s *Server) TranslationOne(c echo.Context) error {
 	language, err := s.translator.Language(c.Param("id"))
 	if err != nil {
@@ -110,9 +187,55 @@ func (s *Server) TranslationOne(c echo.Context) error {
 	return c.JSON(http.StatusOK, language)
 }
 
+// Translate resolves ?key= against the ?locales= fallback chain (a
+// comma-separated list, e.g. "de-CH,de,en"), walking BCP-47 subtags and the
+// translator's DefaultLocale before giving up and returning the key.
+func (s *Server) Translate(c echo.Context) error {
+	key := c.QueryParam("key")
+	var locales []string
+	if param := c.QueryParam("locales"); param != "" {
+		locales = strings.Split(param, ",")
+	}
+	value := s.translator.T(key, locales...)
+	return c.JSON(http.StatusOK, map[string]string{
+		"key":   key,
+		"value": value,
+	})
+}
+
+// Events streams Translator reload notifications to the client as
+// server-sent events, so admin UIs can be notified as translation files are
+// hot-reloaded. Each connection gets its own subscription, so concurrent
+// clients all see every event instead of splitting them.
+func (s *Server) Events(c echo.Context) error {
+	c.Response().Header().Set(echo.HeaderContentType, "text/event-stream")
+	c.Response().Header().Set("Cache-Control", "no-cache")
+	c.Response().Header().Set("Connection", "keep-alive")
+	c.Response().WriteHeader(http.StatusOK)
+
+	events, unsubscribe := s.translator.Subscribe()
+	defer unsubscribe()
+
+	flusher, _ := c.Response().(http.Flusher)
+	for event := range events {
+		b, err := json.Marshal(event)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(c.Response(), "data: %s\n\n", b)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	return nil
+}
+
 func (s *Server) handleError(err error) *echo.HTTPError {
 	if err == ErrLanguageNotFound {
 		return echo.NewHTTPError(http.StatusNotFound, err.Error())
 	}
+	if err == ErrInvalidPluralForms {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
 	return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 }
\ No newline at end of file
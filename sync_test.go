@@ -0,0 +1,91 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestTranslator(t *testing.T, files map[string]string) *Translator {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "translator-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tr, err := NewTranslator(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tr
+}
+
+func TestSyncMarksMissingKeysUntranslated(t *testing.T) {
+	tr := newTestTranslator(t, map[string]string{
+		"en.json": `{"a": "A", "b": "B"}`,
+		"de.json": `{"a": "Ade"}`,
+	})
+
+	if err := tr.Sync("en", false); err != nil {
+		t.Fatal(err)
+	}
+
+	de := tr.languages["de"]
+	if de["a"].Untranslated {
+		t.Error("pre-existing key should not be marked untranslated")
+	}
+	if !de["b"].Untranslated {
+		t.Error("key missing from target language should be marked untranslated")
+	}
+	if de["b"].Template != "B" {
+		t.Errorf("untranslated entry should carry the source template, got %q", de["b"].Template)
+	}
+}
+
+func TestSyncFilesWritesSplitFilesAndBaseFile(t *testing.T) {
+	tr := newTestTranslator(t, map[string]string{
+		"en.json": `{"a": "A", "b": "B"}`,
+		"de.json": `{"a": "Ade"}`,
+	})
+
+	if err := tr.Set("a", "A-updated", "en"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tr.SyncFiles("en", false, true); err != nil {
+		t.Fatal(err)
+	}
+
+	enOnDisk, err := ioutil.ReadFile(filepath.Join(tr.directory, "en.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(enOnDisk), "A-updated") {
+		t.Errorf("en.json on disk should reflect the Set update, got %s", enOnDisk)
+	}
+
+	translated, err := ioutil.ReadFile(filepath.Join(tr.directory, "de.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(translated), "Ade") || strings.Contains(string(translated), "\"b\"") {
+		t.Errorf("de.json should only contain already-translated keys, got %s", translated)
+	}
+
+	untranslated, err := ioutil.ReadFile(filepath.Join(tr.directory, "de.untranslated.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(untranslated), "\"b\"") {
+		t.Errorf("de.untranslated.json should contain the missing key, got %s", untranslated)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"text/template"
+)
+
+// templateCacheKey identifies a parsed *template.Template by the exact
+// inputs that produced it, so a Set/SetPlural that changes the underlying
+// string invalidates only the affected entry.
+type templateCacheKey struct {
+	lang     string
+	key      string
+	template string
+}
+
+// Render looks up key in lang, selecting its CLDR plural form for
+// data["Count"] first if the translation holds plural forms, then executes
+// the result as a text/template against data. Parsed templates are cached
+// by (lang, key, templateString) and invalidated on Set/SetPlural/Remove.
+func (t *Translator) Render(key, lang string, data map[string]interface{}) (string, error) {
+	t.mu.RLock()
+	trans, ok := t.languages[lang]
+	if !ok {
+		t.mu.RUnlock()
+		return "", ErrLanguageNotFound
+	}
+	translation, ok := trans[key]
+	if !ok {
+		t.mu.RUnlock()
+		return key, nil
+	}
+
+	raw := translation.Template
+	if translation.IsPlural() {
+		category := pluralCategory(lang, toFloat(data["Count"]))
+		if form, ok := translation.Plurals[category]; ok {
+			raw = form
+		} else if form, ok := translation.Plurals[PluralOther]; ok {
+			raw = form
+		}
+	}
+	t.mu.RUnlock()
+
+	tmpl, err := t.parsedTemplate(lang, key, raw)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// parsedTemplate returns the cached *template.Template for (lang, key, raw),
+// parsing and caching it first if needed.
+func (t *Translator) parsedTemplate(lang, key, raw string) (*template.Template, error) {
+	cacheKey := templateCacheKey{lang: lang, key: key, template: raw}
+
+	t.templateCacheMu.RLock()
+	tmpl, ok := t.templateCache[cacheKey]
+	t.templateCacheMu.RUnlock()
+	if ok {
+		return tmpl, nil
+	}
+
+	tmpl, err := template.New(key).Funcs(template.FuncMap{
+		"plural": pluralTemplateFunc(lang),
+	}).Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	t.templateCacheMu.Lock()
+	if t.templateCache == nil {
+		t.templateCache = make(map[templateCacheKey]*template.Template)
+	}
+	t.templateCache[cacheKey] = tmpl
+	t.templateCacheMu.Unlock()
+	return tmpl, nil
+}
+
+// invalidateTemplateCache drops every cached template for (lang, key),
+// regardless of the template string it was parsed from.
+func (t *Translator) invalidateTemplateCache(lang, key string) {
+	t.templateCacheMu.Lock()
+	for cacheKey := range t.templateCache {
+		if cacheKey.lang == lang && cacheKey.key == key {
+			delete(t.templateCache, cacheKey)
+		}
+	}
+	t.templateCacheMu.Unlock()
+}
+
+// pluralTemplateFunc builds the "plural" template func for lang, letting
+// messages like `{{plural .Count "item" "items"}}` pick a word form without
+// a separate GetPlural lookup. forms are ordered least-to-most plural; the
+// "one" category selects the first form, every other category the last.
+func pluralTemplateFunc(lang string) func(interface{}, ...string) string {
+	return func(n interface{}, forms ...string) string {
+		if len(forms) == 0 {
+			return ""
+		}
+		if pluralCategory(lang, toFloat(n)) == PluralOne {
+			return forms[0]
+		}
+		return forms[len(forms)-1]
+	}
+}
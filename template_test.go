@@ -0,0 +1,73 @@
+package main
+
+import "testing"
+
+func TestRenderNamedParameters(t *testing.T) {
+	tr := &Translator{
+		languages: map[string]Language{
+			"en": {"greeting": Translation{Key: "greeting", Template: "Hello {{.Name}}, you have {{.Count}} messages"}},
+		},
+	}
+
+	got, err := tr.Render("greeting", "en", map[string]interface{}{"Name": "Ada", "Count": 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Hello Ada, you have 3 messages"
+	if got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderSelectsPluralFormForCount(t *testing.T) {
+	tr := &Translator{
+		languages: map[string]Language{
+			"en": {"items": Translation{Key: "items", Plurals: map[string]string{
+				PluralOne:   "{{.Count}} {{plural .Count \"item\" \"items\"}}",
+				PluralOther: "{{.Count}} {{plural .Count \"item\" \"items\"}}",
+			}}},
+		},
+	}
+
+	one, err := tr.Render("items", "en", map[string]interface{}{"Count": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if one != "1 item" {
+		t.Errorf("Render(Count=1) = %q, want %q", one, "1 item")
+	}
+
+	many, err := tr.Render("items", "en", map[string]interface{}{"Count": 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if many != "5 items" {
+		t.Errorf("Render(Count=5) = %q, want %q", many, "5 items")
+	}
+}
+
+func TestRenderCacheInvalidatedOnSet(t *testing.T) {
+	tr := newTestTranslator(t, map[string]string{
+		"en.json": `{"greeting": "Hello {{.Name}}"}`,
+	})
+
+	first, err := tr.Render("greeting", "en", map[string]interface{}{"Name": "Ada"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != "Hello Ada" {
+		t.Errorf("Render() = %q, want %q", first, "Hello Ada")
+	}
+
+	if err := tr.Set("greeting", "Hi {{.Name}}!", "en"); err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := tr.Render("greeting", "en", map[string]interface{}{"Name": "Ada"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second != "Hi Ada!" {
+		t.Errorf("Render() after Set = %q, want %q (stale cache?)", second, "Hi Ada!")
+	}
+}
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -10,11 +9,26 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"text/template"
+
+	fsnotify "gopkg.in/fsnotify.v1"
 )
 
 type Translation struct {
 	Key      string `json:"id"`
-	Template string `json:"template"`
+	Template string `json:"template,omitempty"`
+	// Plurals holds CLDR plural-form templates (zero, one, two, few, many,
+	// other) for keys that vary by count. When set, Template is unused.
+	Plurals map[string]string `json:"plurals,omitempty"`
+	// Untranslated marks an entry inserted by Sync from the source language
+	// because the target language had no translation for this key yet.
+	Untranslated bool `json:"untranslated,omitempty"`
+}
+
+// IsPlural reports whether this translation holds plural forms rather than
+// a flat template.
+func (t Translation) IsPlural() bool {
+	return len(t.Plurals) > 0
 }
 
 type Language map[string]Translation
@@ -23,12 +37,36 @@ type Translator struct {
 	directory    string
 	languagesRaw map[string][]byte
 	languages    map[string]Language
-	mutexRaw     sync.Mutex
-	mutexLang    sync.Mutex
+	// DefaultLocale is appended to the end of every fallback chain passed
+	// to T, so callers don't need to repeat it on every request.
+	DefaultLocale  string
+	languageFormat map[string]Format
+	// mu guards languages, languagesRaw and languageFormat. Translator is
+	// shared between HTTP handlers and the background file watcher started
+	// by Watch, so every access to those maps - reads included - must hold
+	// mu; only mu.Lock()ed writers and mu.RLock()ed readers are safe.
+	mu sync.RWMutex
+
+	watcher *fsnotify.Watcher
+	// events is the internal sink watchLoop emits reload/remove events to;
+	// a single broadcast goroutine (started by startBroadcast) fans each one
+	// out to every subscriber channel, so multiple concurrent Subscribe
+	// callers each see every event instead of splitting them.
+	events        chan ReloadEvent
+	broadcastOnce sync.Once
+	subscribersMu sync.Mutex
+	subscribers   map[chan ReloadEvent]struct{}
+
+	templateCacheMu sync.RWMutex
+	templateCache   map[templateCacheKey]*template.Template
 }
 
 var (
 	ErrLanguageNotFound = errors.New("Language is not loaded")
+	// ErrInvalidPluralForms is returned when a plural-form object doesn't
+	// have exactly CLDR category names (zero, one, two, few, many, other)
+	// as its keys.
+	ErrInvalidPluralForms = errors.New("plural forms must be keyed by CLDR categories (zero, one, two, few, many, other)")
 )
 
 func NewTranslator(directory string) (*Translator, error) {
@@ -41,9 +79,10 @@ func NewTranslator(directory string) (*Translator, error) {
 		return nil, fmt.Errorf("%s is no directory", directory)
 	}
 	t := &Translator{
-		directory:    directory,
-		languagesRaw: make(map[string][]byte),
-		languages:    make(map[string]Language),
+		directory:      directory,
+		languagesRaw:   make(map[string][]byte),
+		languages:      make(map[string]Language),
+		languageFormat: make(map[string]Format),
 	}
 	// load translations
 	if err := t.Load(); err != nil {
@@ -63,24 +102,27 @@ func (t *Translator) Load() error {
 		if info.IsDir() {
 			return nil
 		}
-		// match json files
-		if matched, _ := filepath.Match("*.json", info.Name()); matched {
-			// open file
-			file, err := os.Open(path)
-			if err != nil {
-				return err
-			}
-			// read all bytes
-			b, err := ioutil.ReadAll(file)
-			if err != nil {
-				return err
-			}
-			name := strings.Replace(info.Name(), ".json", "", -1)
-			// add language
-			err = t.parseLanguage(name, b)
-			if err != nil {
-				return err
-			}
+		// dispatch on the registered format for this extension
+		ext := strings.TrimPrefix(filepath.Ext(info.Name()), ".")
+		format, ok := formatForExt(ext)
+		if !ok {
+			return nil
+		}
+		// open file
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		// read all bytes
+		b, err := ioutil.ReadAll(file)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(info.Name(), filepath.Ext(info.Name()))
+		// add language
+		err = t.parseLanguage(name, b, format)
+		if err != nil {
+			return err
 		}
 		return nil
 	})
@@ -88,31 +130,92 @@ func (t *Translator) Load() error {
 
 // Get returns the value of a key
 func (t *Translator) Get(key, lang string) string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.getLocked(key, lang)
+}
+
+// getLocked is Get's implementation. Callers must already hold t.mu for
+// reading or writing.
+func (t *Translator) getLocked(key, lang string) string {
 	// find language
 	if trans, ok := t.languages[lang]; ok {
 		if value, ok := trans[key]; ok {
+			if value.IsPlural() {
+				return value.Plurals[PluralOther]
+			}
 			return value.Template
 		}
 	}
 	return key
 }
 
+// GetPlural returns the value of a key, selecting the CLDR plural form that
+// matches count for lang. Falls back to "other", then to Get, when the key
+// holds no plural forms or the selected category is missing.
+func (t *Translator) GetPlural(key, lang string, count interface{}) string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if trans, ok := t.languages[lang]; ok {
+		if value, ok := trans[key]; ok && value.IsPlural() {
+			category := pluralCategory(lang, toFloat(count))
+			if form, ok := value.Plurals[category]; ok {
+				return form
+			}
+			if form, ok := value.Plurals[PluralOther]; ok {
+				return form
+			}
+		}
+	}
+	return t.getLocked(key, lang)
+}
+
 // Set the value for a key
 func (t *Translator) Set(key, value, lang string) error {
+	t.mu.Lock()
 	// find language
-	if trans, ok := t.languages[lang]; ok {
-		// update key
-		t.mutexLang.Lock()
-		entry := trans[key]
-		entry.Template = value
-		trans[key] = entry
-		t.mutexLang.Unlock()
-		return nil
+	trans, ok := t.languages[lang]
+	if !ok {
+		t.mu.Unlock()
+		return ErrLanguageNotFound
+	}
+	// update key
+	entry := trans[key]
+	entry.Key = key
+	entry.Template = value
+	entry.Plurals = nil
+	trans[key] = entry
+	t.mu.Unlock()
+	t.invalidateTemplateCache(lang, key)
+	return nil
+}
+
+// SetPlural sets the CLDR plural forms for a key, replacing any flat
+// template previously stored there. Returns ErrInvalidPluralForms if forms
+// isn't keyed exactly by CLDR category names.
+func (t *Translator) SetPlural(key string, forms map[string]string, lang string) error {
+	if !isValidPluralForms(forms) {
+		return ErrInvalidPluralForms
 	}
-	return ErrLanguageNotFound
+	t.mu.Lock()
+	trans, ok := t.languages[lang]
+	if !ok {
+		t.mu.Unlock()
+		return ErrLanguageNotFound
+	}
+	entry := trans[key]
+	entry.Key = key
+	entry.Template = ""
+	entry.Plurals = forms
+	trans[key] = entry
+	t.mu.Unlock()
+	t.invalidateTemplateCache(lang, key)
+	return nil
 }
 
 func (t *Translator) GetAll(key string) map[string]string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
 	values := make(map[string]string)
 	for langCode, language := range t.languages {
 		if translation, ok := language[key]; ok {
@@ -123,20 +226,25 @@ func (t *Translator) GetAll(key string) map[string]string {
 }
 
 func (t *Translator) Remove(key, lang string) error {
-	if translations, ok := t.languages[lang]; ok {
-		// update key
-		t.mutexLang.Lock()
-		delete(translations, key)
-		t.mutexLang.Unlock()
-		return nil
+	t.mu.Lock()
+	translations, ok := t.languages[lang]
+	if !ok {
+		t.mu.Unlock()
+		return ErrLanguageNotFound
 	}
-	return ErrLanguageNotFound
+	// update key
+	delete(translations, key)
+	t.mu.Unlock()
+	t.invalidateTemplateCache(lang, key)
+	return nil
 }
 
 func (t *Translator) Languages() ([]map[string]interface{}, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
 	plain := make([]map[string]interface{}, 0, len(t.languages))
 	for langCode := range t.languages {
-		language, err := t.Language(langCode)
+		language, err := t.languageLocked(langCode)
 		if err != nil {
 			return nil, err
 		}
@@ -149,6 +257,14 @@ func (t *Translator) Languages() ([]map[string]interface{}, error) {
 }
 
 func (t *Translator) Language(lang string) (map[string]interface{}, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.languageLocked(lang)
+}
+
+// languageLocked is Language's implementation. Callers must already hold
+// t.mu for reading or writing.
+func (t *Translator) languageLocked(lang string) (map[string]interface{}, error) {
 	if translations, ok := t.languages[lang]; ok {
 		plain := make([]Translation, 0, len(translations))
 		for _, value := range translations {
@@ -163,34 +279,57 @@ func (t *Translator) Language(lang string) (map[string]interface{}, error) {
 }
 
 func (t *Translator) AddLanguage(lang, base string) (map[string]interface{}, error) {
-	if translations, ok := t.languages[base]; ok {
-		t.mutexLang.Lock()
-		t.languages[lang] = translations
-		t.mutexLang.Unlock()
-		return t.Language(lang)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	translations, ok := t.languages[base]
+	if !ok {
+		return nil, ErrLanguageNotFound
 	}
-	return nil, ErrLanguageNotFound
+	t.languages[lang] = translations
+	// new language inherits the base language's source format
+	t.languageFormat[lang] = t.formatForLocked(base)
+	return t.languageLocked(lang)
 }
 
 func (t *Translator) RemoveLanguage(lang string) error {
-	if _, ok := t.languages[lang]; ok {
-		// delete opt translation
-		t.mutexLang.Lock()
-		delete(t.languages, lang)
-		t.mutexLang.Unlock()
-		// delete raw
-		t.mutexRaw.Lock()
-		delete(t.languagesRaw, lang)
-		t.mutexRaw.Unlock()
-		// delete file
-		return os.Remove(path.Join(t.directory, lang+".json"))
-	}
-	return ErrLanguageNotFound
+	t.mu.Lock()
+	if _, ok := t.languages[lang]; !ok {
+		t.mu.Unlock()
+		return ErrLanguageNotFound
+	}
+	// delete opt translation
+	delete(t.languages, lang)
+	// delete raw
+	ext := t.formatForLocked(lang).Extensions()[0]
+	delete(t.languagesRaw, lang)
+	delete(t.languageFormat, lang)
+	t.mu.Unlock()
+	// delete file
+	return os.Remove(path.Join(t.directory, lang+"."+ext))
+}
+
+// formatForLocked returns the source format for lang, defaulting to JSON
+// for languages created at runtime without a backing file. Callers must
+// already hold t.mu for reading or writing.
+func (t *Translator) formatForLocked(lang string) Format {
+	if format, ok := t.languageFormat[lang]; ok {
+		return format
+	}
+	return jsonFormat{}
 }
 
-// Sync
+// Sync merges base into every other loaded language: existing translations
+// are kept, and keys missing from a target language are inserted from base,
+// marked Untranslated.
 func (t *Translator) Sync(base string, orphanRemoval bool) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.syncLocked(base, orphanRemoval)
+}
 
+// syncLocked is Sync's implementation. Callers must already hold t.mu for
+// writing.
+func (t *Translator) syncLocked(base string, orphanRemoval bool) error {
 	baseLanguage, ok := t.languages[base]
 	if !ok {
 		return ErrLanguageNotFound
@@ -201,9 +340,7 @@ func (t *Translator) Sync(base string, orphanRemoval bool) error {
 			if langCode != base {
 				for key := range language {
 					if _, ok := baseLanguage[key]; !ok {
-						t.mutexLang.Lock()
 						delete(language, key)
-						t.mutexLang.Unlock()
 					}
 				}
 			}
@@ -215,9 +352,11 @@ func (t *Translator) Sync(base string, orphanRemoval bool) error {
 			if langCode != base {
 				// check if key exists in language
 				if _, ok := language[key]; !ok {
-					t.mutexLang.Lock()
-					language[key] = translation
-					t.mutexLang.Unlock()
+					// insert the source template, marked as untranslated
+					// so translators can filter for outstanding work
+					untranslated := translation
+					untranslated.Untranslated = true
+					language[key] = untranslated
 				}
 			}
 		}
@@ -226,43 +365,130 @@ func (t *Translator) Sync(base string, orphanRemoval bool) error {
 	return nil
 }
 
-// Save all changes to file
-func (t *Translator) Save(indent bool) error {
-	for lang := range t.languages {
-		if err := t.syncRaw(lang, indent); err != nil {
+// SyncFiles merges base into every other language like Sync, then writes
+// two files per target language instead of silently overwriting the single
+// translation file: "<lang>.<ext>" holding already-translated keys, and
+// "<lang>.untranslated.<ext>" holding the keys still needing work. The base
+// language itself is written back to its own single file, same as Save.
+func (t *Translator) SyncFiles(base string, orphanRemoval, indent bool) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.syncLocked(base, orphanRemoval); err != nil {
+		return err
+	}
+	for langCode, language := range t.languages {
+		if langCode == base {
+			continue
+		}
+		translated := make(map[string]interface{})
+		untranslated := make(map[string]interface{})
+		for key, translation := range language {
+			target := translated
+			if translation.Untranslated {
+				target = untranslated
+			}
+			if translation.IsPlural() {
+				insertPlural(key, translation.Plurals, target)
+				continue
+			}
+			insert(key, translation.Template, target)
+		}
+		format := t.formatForLocked(langCode)
+		ext := format.Extensions()[0]
+		if err := t.writeSyncFile(langCode+"."+ext, format, translated, indent); err != nil {
 			return err
 		}
-		// save to file
-		f, err := os.Create(path.Join(t.directory, lang+".json"))
-		if err != nil {
+		if err := t.writeSyncFile(langCode+".untranslated."+ext, format, untranslated, indent); err != nil {
 			return err
 		}
-		_, err = f.Write(t.languagesRaw[lang])
-		if err != nil {
+	}
+	// write the (possibly just-updated) base language back to its own file,
+	// same as Save would, without touching the target languages' split
+	// files we just wrote above
+	if err := t.syncRawLocked(base, indent); err != nil {
+		return err
+	}
+	return t.writeRawLocked(base)
+}
+
+// writeRawLocked writes the cached languagesRaw bytes for lang to its file,
+// in its source format. Callers must already hold t.mu for reading or
+// writing.
+func (t *Translator) writeRawLocked(lang string) error {
+	ext := t.formatForLocked(lang).Extensions()[0]
+	f, err := os.Create(path.Join(t.directory, lang+"."+ext))
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(t.languagesRaw[lang]); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func (t *Translator) writeSyncFile(name string, format Format, data map[string]interface{}, indent bool) error {
+	b, err := format.Marshal(data, indent)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(path.Join(t.directory, name))
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// Bulk folds a completed untranslated file back into lang, clearing the
+// Untranslated flag on every key it contains.
+func (t *Translator) Bulk(lang string, data map[string]interface{}) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.languages[lang]; !ok {
+		return ErrLanguageNotFound
+	}
+	completed := t.extractKeys("", data)
+	for key, translation := range completed {
+		translation.Untranslated = false
+		t.languages[lang][key] = translation
+	}
+	return nil
+}
+
+// Save all changes to file
+func (t *Translator) Save(indent bool) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for lang := range t.languages {
+		if err := t.syncRawLocked(lang, indent); err != nil {
 			return err
 		}
-		err = f.Close()
-		if err != nil {
+		// save to file, in the format the language was loaded from
+		if err := t.writeRawLocked(lang); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (t *Translator) parseLanguage(name string, b []byte) error {
-	var lang map[string]interface{}
-	err := json.Unmarshal(b, &lang)
+func (t *Translator) parseLanguage(name string, b []byte, format Format) error {
+	lang, err := format.Unmarshal(b)
 	if err != nil {
 		return err
 	}
+	// extractKeys only reads its own arguments, so it can run outside the
+	// lock; only the map assignments below touch shared state.
+	extracted := t.extractKeys("", lang)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	// add language to map
-	t.mutexRaw.Lock()
 	t.languagesRaw[name] = b
-	t.mutexRaw.Unlock()
+	t.languageFormat[name] = format
 	// add optimized translations
-	t.mutexLang.Lock()
-	t.languages[name] = t.extractKeys("", lang)
-	t.mutexLang.Unlock()
+	t.languages[name] = extracted
 
 	return nil
 }
@@ -278,9 +504,14 @@ func (t *Translator) extractKeys(prefix string, m map[string]interface{}) map[st
 		case string:
 			keys[key] = Translation{Template: v.(string), Key: key}
 		case map[string]interface{}:
-			sub := t.extractKeys(key, v.(map[string]interface{}))
+			sub := v.(map[string]interface{})
+			if forms, ok := asPluralForms(sub); ok {
+				keys[key] = Translation{Plurals: forms, Key: key}
+				continue
+			}
+			extracted := t.extractKeys(key, sub)
 			// merge
-			for sk, vk := range sub {
+			for sk, vk := range extracted {
 				keys[sk] = vk
 			}
 		}
@@ -288,28 +519,28 @@ func (t *Translator) extractKeys(prefix string, m map[string]interface{}) map[st
 	return keys
 }
 
-func (t *Translator) syncRaw(lang string, indent bool) (err error) {
-	if language, ok := t.languages[lang]; ok {
-		target := make(map[string]interface{})
-		for key, translation := range language {
-			insert(key, translation.Template, target)
-		}
-		var b []byte
-		if indent {
-			b, err = json.MarshalIndent(target, "", "  ")
-		} else {
-			b, err = json.Marshal(target)
-		}
-		if err != nil {
-			return err
+// syncRawLocked re-serializes lang's in-memory translations into
+// t.languagesRaw. Callers must already hold t.mu for writing.
+func (t *Translator) syncRawLocked(lang string, indent bool) error {
+	language, ok := t.languages[lang]
+	if !ok {
+		return ErrLanguageNotFound
+	}
+	target := make(map[string]interface{})
+	for key, translation := range language {
+		if translation.IsPlural() {
+			insertPlural(key, translation.Plurals, target)
+			continue
 		}
-		// update raw language
-		t.mutexRaw.Lock()
-		t.languagesRaw[lang] = b
-		t.mutexRaw.Unlock()
-		return nil
+		insert(key, translation.Template, target)
+	}
+	b, err := t.formatForLocked(lang).Marshal(target, indent)
+	if err != nil {
+		return err
 	}
-	return ErrLanguageNotFound
+	// update raw language
+	t.languagesRaw[lang] = b
+	return nil
 }
 
 func insert(key, value string, target map[string]interface{}) {
@@ -330,3 +561,25 @@ func insert(key, value string, target map[string]interface{}) {
 	insert(keyParts[1], value, child)
 	target[keyParts[0]] = child
 }
+
+// insertPlural re-nests a key's CLDR plural forms into target, mirroring
+// insert but writing the whole forms map as the leaf value.
+func insertPlural(key string, forms map[string]string, target map[string]interface{}) {
+	if !strings.Contains(key, ".") {
+		leaf := make(map[string]interface{}, len(forms))
+		for category, value := range forms {
+			leaf[category] = value
+		}
+		target[key] = leaf
+		return
+	}
+	keyParts := strings.SplitN(key, ".", 2)
+	var child map[string]interface{}
+	if c, ok := target[keyParts[0]]; ok {
+		child = c.(map[string]interface{})
+	} else {
+		child = make(map[string]interface{})
+	}
+	insertPlural(keyParts[1], forms, child)
+	target[keyParts[0]] = child
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	fsnotify "gopkg.in/fsnotify.v1"
+)
+
+// watchDebounce coalesces the burst of create/write events a single editor
+// "save" tends to emit into one reload per file.
+const watchDebounce = 200 * time.Millisecond
+
+// ReloadEventType describes what happened to a language as a result of a
+// file system change picked up by Translator.Watch.
+type ReloadEventType string
+
+const (
+	ReloadEventUpdated ReloadEventType = "updated"
+	ReloadEventRemoved ReloadEventType = "removed"
+)
+
+// ReloadEvent is broadcast to every Translator.Subscribe() caller whenever a watched
+// translation file is reloaded or its language is dropped.
+type ReloadEvent struct {
+	Language string          `json:"language"`
+	Type     ReloadEventType `json:"type"`
+}
+
+// NewTranslatorWithWatch loads directory like NewTranslator and additionally
+// starts watching it for changes, re-parsing files as they're created,
+// written or renamed, and dropping languages whose file is removed.
+func NewTranslatorWithWatch(directory string) (*Translator, error) {
+	t, err := NewTranslator(directory)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.Watch(context.Background()); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Subscribe registers a new per-connection channel that receives every
+// ReloadEvent broadcast while Watch is running, so callers (e.g. the HTTP
+// layer) can push SSE notifications to admin UIs. Call the returned
+// unsubscribe func once the connection is done to release the channel.
+func (t *Translator) Subscribe() (<-chan ReloadEvent, func()) {
+	t.startBroadcast()
+	ch := make(chan ReloadEvent, 16)
+	t.subscribersMu.Lock()
+	t.subscribers[ch] = struct{}{}
+	t.subscribersMu.Unlock()
+	unsubscribe := func() {
+		t.subscribersMu.Lock()
+		delete(t.subscribers, ch)
+		t.subscribersMu.Unlock()
+		close(ch)
+	}
+	return ch, unsubscribe
+}
+
+// startBroadcast lazily starts the single goroutine that fans events out of
+// t.events to every subscriber channel registered via Subscribe. It's safe
+// to call more than once; only the first call has any effect.
+func (t *Translator) startBroadcast() {
+	t.broadcastOnce.Do(func() {
+		if t.events == nil {
+			t.events = make(chan ReloadEvent, 16)
+		}
+		if t.subscribers == nil {
+			t.subscribers = make(map[chan ReloadEvent]struct{})
+		}
+		go func() {
+			for event := range t.events {
+				t.subscribersMu.Lock()
+				for ch := range t.subscribers {
+					select {
+					case ch <- event:
+					default:
+					}
+				}
+				t.subscribersMu.Unlock()
+			}
+		}()
+	})
+}
+
+// Watch starts an fsnotify watcher on the translator's directory. Changes
+// to files in a registered format are debounced and re-parsed under t.mu;
+// removed files drop their language. Reload/remove notifications are
+// broadcast to every Subscribe caller. Watching stops when ctx is cancelled.
+func (t *Translator) Watch(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watcher.Add(t.directory); err != nil {
+		watcher.Close()
+		return err
+	}
+	t.watcher = watcher
+	t.startBroadcast()
+	go t.watchLoop(ctx, watcher)
+	return nil
+}
+
+func (t *Translator) watchLoop(ctx context.Context, watcher *fsnotify.Watcher) {
+	defer watcher.Close()
+	pending := make(map[string]struct{})
+	timer := time.NewTimer(watchDebounce)
+	if !timer.Stop() {
+		<-timer.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if !isWatchedFile(event.Name) {
+				continue
+			}
+			pending[event.Name] = struct{}{}
+			timer.Reset(watchDebounce)
+		case <-timer.C:
+			for name := range pending {
+				t.reloadWatchedFile(name)
+			}
+			pending = make(map[string]struct{})
+		case _, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// isWatchedFile reports whether path has an extension handled by a
+// registered Format.
+func isWatchedFile(path string) bool {
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	_, ok := formatForExt(ext)
+	return ok
+}
+
+// reloadWatchedFile re-parses path's language, or drops it if the file no
+// longer exists (covers both plain removes and the remove+create pattern
+// many editors use for renames).
+func (t *Translator) reloadWatchedFile(path string) {
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	format, ok := formatForExt(ext)
+	if !ok {
+		return
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			t.dropLanguage(name)
+			t.emit(ReloadEvent{Language: name, Type: ReloadEventRemoved})
+		}
+		return
+	}
+	if err := t.parseLanguage(name, b, format); err != nil {
+		return
+	}
+	t.emit(ReloadEvent{Language: name, Type: ReloadEventUpdated})
+}
+
+// dropLanguage removes a language from both the optimized and raw maps.
+func (t *Translator) dropLanguage(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.languages, name)
+	delete(t.languagesRaw, name)
+	delete(t.languageFormat, name)
+}
+
+func (t *Translator) emit(event ReloadEvent) {
+	if t.events == nil {
+		return
+	}
+	select {
+	case t.events <- event:
+	default:
+	}
+}
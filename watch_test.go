@@ -0,0 +1,107 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIsWatchedFile(t *testing.T) {
+	if !isWatchedFile("/tmp/dir/de.json") {
+		t.Error("expected .json to be watched")
+	}
+	if !isWatchedFile("/tmp/dir/de.yaml") {
+		t.Error("expected .yaml to be watched")
+	}
+	if isWatchedFile("/tmp/dir/de.bak") {
+		t.Error("expected .bak to not be watched")
+	}
+}
+
+func TestReloadWatchedFilePicksUpChangesAndRemovals(t *testing.T) {
+	tr := newTestTranslator(t, map[string]string{
+		"en.json": `{"a": "A"}`,
+	})
+	events, unsubscribe := tr.Subscribe()
+	defer unsubscribe()
+
+	path := filepath.Join(tr.directory, "fr.json")
+	if err := ioutil.WriteFile(path, []byte(`{"a": "Afr"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tr.reloadWatchedFile(path)
+
+	if got := tr.Get("a", "fr"); got != "Afr" {
+		t.Errorf("Get(a, fr) after reload = %q, want %q", got, "Afr")
+	}
+	select {
+	case event := <-events:
+		if event.Language != "fr" || event.Type != ReloadEventUpdated {
+			t.Errorf("unexpected event: %+v", event)
+		}
+	case <-time.After(time.Second):
+		t.Error("expected an updated ReloadEvent")
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	tr.reloadWatchedFile(path)
+
+	if _, ok := tr.languages["fr"]; ok {
+		t.Error("expected language to be dropped after its file was removed")
+	}
+	select {
+	case event := <-events:
+		if event.Language != "fr" || event.Type != ReloadEventRemoved {
+			t.Errorf("unexpected event: %+v", event)
+		}
+	case <-time.After(time.Second):
+		t.Error("expected a removed ReloadEvent")
+	}
+}
+
+// TestConcurrentReloadAndReadDoesNotRace reproduces the hot-reload data race:
+// a background writer calling parseLanguage (as watchLoop does) concurrently
+// with reader call sites across the package. Run with -race.
+func TestConcurrentReloadAndReadDoesNotRace(t *testing.T) {
+	tr := newTestTranslator(t, map[string]string{
+		"en.json": `{"a": "A", "items": {"one": "one item", "other": "{{.Count}} items"}}`,
+	})
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			b := []byte(`{"a": "A"}`)
+			if err := tr.parseLanguage("en", b, jsonFormat{}); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < 200; i++ {
+		tr.Get("a", "en")
+		tr.GetPlural("items", "en", i)
+		tr.T("a", "en")
+		if _, err := tr.Render("a", "en", nil); err != nil {
+			t.Error(err)
+		}
+		if _, err := tr.Language("en"); err != nil {
+			t.Error(err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+}